@@ -0,0 +1,260 @@
+package jiracli
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mrjones/oauth"
+	"gopkg.in/AlecAivazis/survey.v1"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// Authenticator abstracts how a request is credentialed and how the client
+// reacts when Jira tells us the request was not authenticated.  Register
+// selects one implementation based on GlobalOptions.AuthType and installs
+// it as an oreo pre/post callback pair.
+type Authenticator interface {
+	// Prepare decorates an outgoing request with whatever credentials
+	// this backend uses (cookies, headers, request signing, ...).
+	Prepare(req *http.Request) error
+
+	// HandleUnauthorized is invoked whenever a response comes back with
+	// an empty or anonymous X-Ausername header.  It returns true if the
+	// triggering request should be retried after handling.
+	HandleUnauthorized(resp *http.Response) (retry bool, err error)
+}
+
+// transportWrapper is an optional extension to Authenticator for backends
+// that have to credential the request at the RoundTripper level instead of
+// by mutating it in Prepare. oauth1Authenticator is the one implementation:
+// the mrjones/oauth consumer signs and sends the request itself, so Register
+// installs it as a transport layered over whatever --insecure/--unixproxy/
+// unix-socket transport is already configured.
+type transportWrapper interface {
+	WrapTransport(next http.RoundTripper) http.RoundTripper
+}
+
+// newAuthenticator selects the Authenticator implementation named by
+// globals.AuthType, defaulting to the historical session-cookie behavior.
+func newAuthenticator(app *kingpin.Application, globals *GlobalOptions) (Authenticator, error) {
+	switch globals.AuthType.Value {
+	case "", "session":
+		return &sessionAuthenticator{app: app, globals: globals}, nil
+	case "basic":
+		return &basicAuthenticator{globals: globals}, nil
+	case "pat":
+		return &patAuthenticator{globals: globals}, nil
+	case "oauth1":
+		return newOAuth1Authenticator(globals)
+	default:
+		return nil, fmt.Errorf("unknown --auth-type %q, expected one of: basic, session, pat, oauth1", globals.AuthType.Value)
+	}
+}
+
+// sessionAuthenticator is the original behavior: Jira issues a session
+// cookie on login and oreo's cookie jar carries it on subsequent requests,
+// so there is nothing to add to the outgoing request.  When the session
+// has expired we fall back to running the interactive "login" command.
+type sessionAuthenticator struct {
+	app     *kingpin.Application
+	globals *GlobalOptions
+}
+
+func (a *sessionAuthenticator) Prepare(req *http.Request) error {
+	return nil
+}
+
+func (a *sessionAuthenticator) HandleUnauthorized(resp *http.Response) (bool, error) {
+	// preserve the --quiet value, we need to temporarily disable it so
+	// the normal login output is surpressed
+	defer func(quiet bool) {
+		a.globals.Quiet.Value = quiet
+	}(a.globals.Quiet.Value)
+	a.globals.Quiet.Value = true
+
+	// we are not logged in, so force login now by running the "login" command
+	a.app.Parse([]string{"login"})
+	return true, nil
+}
+
+// basicAuthenticator sends the configured user/password as HTTP Basic auth
+// on every request. The password is resolved at most once per invocation
+// and cached, since Prepare runs on every outgoing request and re-resolving
+// it each time would mean an interactive prompt (or a keychain/pass shell-
+// out) per request instead of once for the whole command.
+type basicAuthenticator struct {
+	globals *GlobalOptions
+
+	resolved bool
+	password string
+}
+
+func (a *basicAuthenticator) Prepare(req *http.Request) error {
+	if !a.resolved {
+		password, err := a.globals.GetPassword()
+		if err != nil {
+			return err
+		}
+		a.password = password
+		a.resolved = true
+	}
+	req.SetBasicAuth(a.globals.User.Value, a.password)
+	return nil
+}
+
+func (a *basicAuthenticator) HandleUnauthorized(resp *http.Response) (bool, error) {
+	return false, fmt.Errorf("request rejected as unauthorized with --auth-type=basic, check --user and the configured password-source")
+}
+
+// GetPassword resolves the password to use for --auth-type=basic. A
+// --password-source (or password-source: config key) of the same
+// keychain:/pass:/file: form as CredentialEntry.SecretRef is resolved via
+// resolveSecretRef; with none configured we fall back to an interactive
+// prompt so the password is never required on the command line or in
+// plaintext config.
+func (g *GlobalOptions) GetPassword() (string, error) {
+	if g.PasswordSource.Value != "" {
+		return resolveSecretRef(g.PasswordSource.Value)
+	}
+	var password string
+	if err := survey.AskOne(
+		&survey.Password{Message: fmt.Sprintf("Password for %s", g.User.Value)},
+		&password,
+		nil,
+	); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+// resolveSecretRef turns a secret reference of the form "keychain:<name>",
+// "pass:<name>" or "file:<path>" into the secret it points at, by shelling
+// out to the macOS keychain or pass(1), or reading the file. It backs both
+// --password-source and CredentialEntry.SecretRef, the two places this repo
+// lets a user point at a secret instead of typing it inline.
+func resolveSecretRef(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, "keychain:"):
+		name := strings.TrimPrefix(ref, "keychain:")
+		out, err := exec.Command("security", "find-generic-password", "-s", "jira", "-a", name, "-w").Output()
+		if err != nil {
+			return "", fmt.Errorf("reading %q from the keychain: %s", name, err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	case strings.HasPrefix(ref, "pass:"):
+		name := strings.TrimPrefix(ref, "pass:")
+		out, err := exec.Command("pass", "show", name).Output()
+		if err != nil {
+			return "", fmt.Errorf("reading %q from pass: %s", name, err)
+		}
+		return strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0], nil
+	case strings.HasPrefix(ref, "file:"):
+		path := strings.TrimPrefix(ref, "file:")
+		if strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			path = filepath.Join(home, strings.TrimPrefix(path, "~/"))
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading %q: %s", path, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		return "", fmt.Errorf("unsupported secret reference %q, expected a keychain:, pass: or file: prefix", ref)
+	}
+}
+
+// patAuthenticator sends a Jira Personal Access Token as a bearer token.
+type patAuthenticator struct {
+	globals *GlobalOptions
+}
+
+func (a *patAuthenticator) Prepare(req *http.Request) error {
+	if a.globals.Token.Value == "" {
+		return fmt.Errorf("--auth-type=pat requires --token (or token: in config) to be set")
+	}
+	req.Header.Set("Authorization", "Bearer "+a.globals.Token.Value)
+	return nil
+}
+
+func (a *patAuthenticator) HandleUnauthorized(resp *http.Response) (bool, error) {
+	return false, fmt.Errorf("request rejected as unauthorized with --auth-type=pat, check the configured token")
+}
+
+// oauth1Authenticator signs requests with OAuth 1.0a using an RSA-SHA1
+// consumer key/private key pair and a previously obtained access
+// token/secret, all persisted via the same figtree-driven config as the
+// rest of GlobalOptions.
+type oauth1Authenticator struct {
+	globals     *GlobalOptions
+	consumer    *oauth.Consumer
+	accessToken *oauth.AccessToken
+}
+
+func newOAuth1Authenticator(globals *GlobalOptions) (*oauth1Authenticator, error) {
+	if globals.OAuth1ConsumerKey.Value == "" || globals.OAuth1PrivateKey.Value == "" {
+		return nil, fmt.Errorf("--auth-type=oauth1 requires --oauth1-consumer-key and --oauth1-private-key")
+	}
+	if globals.OAuth1AccessToken.Value == "" || globals.OAuth1AccessSecret.Value == "" {
+		return nil, fmt.Errorf("--auth-type=oauth1 requires --oauth1-access-token and --oauth1-access-secret")
+	}
+
+	privateKeyPEM, err := ioutil.ReadFile(globals.OAuth1PrivateKey.Value)
+	if err != nil {
+		return nil, fmt.Errorf("reading --oauth1-private-key: %s", err)
+	}
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("--oauth1-private-key %q does not contain a PEM block", globals.OAuth1PrivateKey.Value)
+	}
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --oauth1-private-key: %s", err)
+	}
+
+	consumer := oauth.NewRSAConsumer(
+		globals.OAuth1ConsumerKey.Value,
+		privateKey,
+		oauth.ServiceProvider{},
+	)
+
+	return &oauth1Authenticator{
+		globals:  globals,
+		consumer: consumer,
+		accessToken: &oauth.AccessToken{
+			Token:  globals.OAuth1AccessToken.Value,
+			Secret: globals.OAuth1AccessSecret.Value,
+		},
+	}, nil
+}
+
+// Prepare is a no-op: oauth.Consumer only knows how to sign a request as
+// part of actually sending it, so signing happens in WrapTransport instead.
+func (a *oauth1Authenticator) Prepare(req *http.Request) error {
+	return nil
+}
+
+// WrapTransport makes the consumer sign every request with the access
+// token/secret and hands the signed request to next for the actual round
+// trip, so --insecure/--unixproxy/unix-socket transports still apply.
+func (a *oauth1Authenticator) WrapTransport(next http.RoundTripper) http.RoundTripper {
+	a.consumer.HttpClient = &http.Client{Transport: next}
+	// MakeRoundTripper's error return is always nil; it only wraps the
+	// consumer and access token passed to it.
+	roundTripper, _ := a.consumer.MakeRoundTripper(a.accessToken)
+	return roundTripper
+}
+
+func (a *oauth1Authenticator) HandleUnauthorized(resp *http.Response) (bool, error) {
+	return false, fmt.Errorf("request rejected as unauthorized with --auth-type=oauth1, the access token may need to be re-issued")
+}
@@ -3,6 +3,7 @@ package jiracli
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -30,21 +31,38 @@ type Exit struct {
 }
 
 type GlobalOptions struct {
-	Endpoint       figtree.StringOption `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
-	Insecure       figtree.BoolOption   `yaml:"insecure,omitempty" json:"insecure,omitempty"`
-	PasswordSource figtree.StringOption `yaml:"password-source,omitempty" json:"password-source,omitempty"`
-	Quiet          figtree.BoolOption   `yaml:"quiet,omitempty" json:"quiet,omitempty"`
-	UnixProxy      figtree.StringOption `yaml:"unixproxy,omitempty" json:"unixproxy,omitempty"`
-	User           figtree.StringOption `yaml:"user,omitempty" json:"user,omitempty"`
+	AuthType           figtree.StringOption `yaml:"auth-type,omitempty" json:"auth-type,omitempty"`
+	Credential         figtree.StringOption `yaml:"credential,omitempty" json:"credential,omitempty"`
+	Endpoint           figtree.StringOption `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	Insecure           figtree.BoolOption   `yaml:"insecure,omitempty" json:"insecure,omitempty"`
+	OAuth1AccessSecret figtree.StringOption `yaml:"oauth1-access-secret,omitempty" json:"oauth1-access-secret,omitempty"`
+	OAuth1AccessToken  figtree.StringOption `yaml:"oauth1-access-token,omitempty" json:"oauth1-access-token,omitempty"`
+	OAuth1ConsumerKey  figtree.StringOption `yaml:"oauth1-consumer-key,omitempty" json:"oauth1-consumer-key,omitempty"`
+	OAuth1PrivateKey   figtree.StringOption `yaml:"oauth1-private-key,omitempty" json:"oauth1-private-key,omitempty"`
+	PasswordSource     figtree.StringOption `yaml:"password-source,omitempty" json:"password-source,omitempty"`
+	Quiet              figtree.BoolOption   `yaml:"quiet,omitempty" json:"quiet,omitempty"`
+	Token              figtree.StringOption `yaml:"token,omitempty" json:"token,omitempty"`
+	UnixProxy          figtree.StringOption `yaml:"unixproxy,omitempty" json:"unixproxy,omitempty"`
+	UnixSocketCA       figtree.StringOption `yaml:"unix-socket-ca,omitempty" json:"unix-socket-ca,omitempty"`
+	UnixSocketCert     figtree.StringOption `yaml:"unix-socket-cert,omitempty" json:"unix-socket-cert,omitempty"`
+	UnixSocketKey      figtree.StringOption `yaml:"unix-socket-key,omitempty" json:"unix-socket-key,omitempty"`
+	User               figtree.StringOption `yaml:"user,omitempty" json:"user,omitempty"`
 }
 
 type CommonOptions struct {
 	Browse       figtree.BoolOption   `yaml:"browse,omitempty" json:"browse,omitempty"`
 	Editor       figtree.StringOption `yaml:"editor,omitempty" json:"editor,omitempty"`
+	JsonPath     figtree.StringOption `yaml:"jsonpath,omitempty" json:"jsonpath,omitempty"`
 	JsonQuery    figtree.StringOption `yaml:"jq,omitempty" json:"jq,omitempty"`
 	JsonQueryRaw figtree.BoolOption   `yaml:"jq-raw,omitempty" json:"jq-raw,omitempty"`
 	SkipEditing  figtree.BoolOption   `yaml:"noedit,omitempty" json:"noedit,omitempty"`
 	Template     figtree.StringOption `yaml:"template,omitempty" json:"template,omitempty"`
+
+	// Preconditions lists dotted JSON paths (e.g. "issuetype", or
+	// "project.key") that EditLoop will refuse to let through if they
+	// differ between the original template render and the user's
+	// edited copy. It is set by command code, not by a flag.
+	Preconditions []string `yaml:"-" json:"-"`
 }
 
 type CommandRegistryEntry struct {
@@ -70,42 +88,42 @@ func Register(app *kingpin.Application, o *oreo.Client, fig *figtree.FigTree, re
 	globals := GlobalOptions{
 		User: figtree.NewStringOption(os.Getenv("USER")),
 	}
+	app.Flag("auth-type", "Authentication backend to use: basic, session, pat or oauth1").SetValue(&globals.AuthType)
+	app.Flag("credential", "Named credential entry to use, see `jira cred list`").SetValue(&globals.Credential)
 	app.Flag("endpoint", "Base URI to use for Jira").Short('e').SetValue(&globals.Endpoint)
 	app.Flag("insecure", "Disable TLS certificate verification").Short('k').SetValue(&globals.Insecure)
+	app.Flag("oauth1-access-secret", "OAuth1 access token secret").SetValue(&globals.OAuth1AccessSecret)
+	app.Flag("oauth1-access-token", "OAuth1 access token").SetValue(&globals.OAuth1AccessToken)
+	app.Flag("oauth1-consumer-key", "OAuth1 consumer key").SetValue(&globals.OAuth1ConsumerKey)
+	app.Flag("oauth1-private-key", "Path to the OAuth1 RSA private key").SetValue(&globals.OAuth1PrivateKey)
+	app.Flag("password-source", "Where to get the --auth-type=basic password: keychain:<name>, pass:<name> or file:<path>").SetValue(&globals.PasswordSource)
 	app.Flag("quiet", "Suppress output to console").Short('Q').SetValue(&globals.Quiet)
+	app.Flag("token", "Personal access token used for authentication with Jira service").SetValue(&globals.Token)
 	app.Flag("unixproxy", "Path for a unix-socket proxy").SetValue(&globals.UnixProxy)
+	app.Flag("unix-socket-ca", "CA certificate used to verify a unix+tls:// endpoint").SetValue(&globals.UnixSocketCA)
+	app.Flag("unix-socket-cert", "Client certificate for mTLS against a unix+tls:// endpoint").SetValue(&globals.UnixSocketCert)
+	app.Flag("unix-socket-key", "Client key for mTLS against a unix+tls:// endpoint").SetValue(&globals.UnixSocketKey)
 	app.Flag("user", "Login name used for authentication with Jira service").Short('u').SetValue(&globals.User)
 
-	app.PreAction(func(_ *kingpin.ParseContext) error {
-		if globals.Insecure.Value {
-			transport := &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true,
-				},
-			}
-			o = o.WithTransport(transport)
-		}
-		if globals.UnixProxy.Value != "" {
-			o = o.WithTransport(unixProxy(globals.UnixProxy.Value))
-		}
-		return nil
-	})
+	var authenticator Authenticator
+
+	o = o.WithPreCallback(
+		func(req *http.Request) (*http.Request, error) {
+			return req, authenticator.Prepare(req)
+		},
+	)
 
 	o = o.WithPostCallback(
 		func(req *http.Request, resp *http.Response) (*http.Response, error) {
 			authUser := resp.Header.Get("X-Ausername")
 			if authUser == "" || authUser == "anonymous" {
-				// preserve the --quiet value, we need to temporarily disable it so
-				// the normal login output is surpressed
-				defer func(quiet bool) {
-					globals.Quiet.Value = quiet
-				}(globals.Quiet.Value)
-				globals.Quiet.Value = true
-
-				// we are not logged in, so force login now by running the "login" command
-				app.Parse([]string{"login"})
-
+				retry, err := authenticator.HandleUnauthorized(resp)
+				if err != nil {
+					return resp, err
+				}
+				if !retry {
+					return resp, nil
+				}
 				// rerun the original request
 				return o.Do(req)
 			}
@@ -130,6 +148,19 @@ func Register(app *kingpin.Application, o *oreo.Client, fig *figtree.FigTree, re
 		cmd := appOrCmd.Command(commandFields[len(commandFields)-1], copy.Entry.Help)
 		LoadConfigs(cmd, fig, &globals)
 
+		// Authenticator/transport construction reads globals fields
+		// (auth-type, token, oauth1-*, unix-socket-*, ...) that may only be
+		// populated by LoadConfigs's PreAction above, so this has to run as
+		// its own PreAction registered after it rather than at the
+		// app level: kingpin always runs app-level PreActions before any
+		// command-level PreAction, which would otherwise build the
+		// authenticator from defaults before config.yml is ever read.
+		cmd.PreAction(func(_ *kingpin.ParseContext) error {
+			var err error
+			o, authenticator, err = buildAuthenticatedClient(app, o, &globals)
+			return err
+		})
+
 		for _, alias := range copy.Aliases {
 			cmd = cmd.Alias(alias)
 		}
@@ -148,6 +179,49 @@ func Register(app *kingpin.Application, o *oreo.Client, fig *figtree.FigTree, re
 	}
 }
 
+// buildAuthenticatedClient applies the resolved --credential, installs the
+// --insecure/--unixproxy/unix-socket transports, and selects the
+// Authenticator named by globals.AuthType, layering its transport (if any)
+// on top. It must run after globals has been populated from config.yml by
+// LoadConfigs, since all of the above can be set there instead of on the
+// command line.
+func buildAuthenticatedClient(app *kingpin.Application, o *oreo.Client, globals *GlobalOptions) (*oreo.Client, Authenticator, error) {
+	if err := ApplyCredential(globals, globals.Credential.Value); err != nil {
+		return nil, nil, err
+	}
+	if globals.Insecure.Value {
+		transport := &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		}
+		o = o.WithTransport(transport)
+	}
+	if globals.UnixProxy.Value != "" {
+		o = o.WithTransport(unixProxy(globals.UnixProxy.Value))
+	}
+	if transport, endpoint, err := unixSocketEndpoint(globals); err != nil {
+		return nil, nil, err
+	} else if transport != nil {
+		o = o.WithTransport(transport)
+		globals.Endpoint = figtree.NewStringOption(endpoint)
+	}
+
+	authenticator, err := newAuthenticator(app, globals)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tw, ok := authenticator.(transportWrapper); ok {
+		next := o.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		o = o.WithTransport(tw.WrapTransport(next))
+	}
+	return o, authenticator, nil
+}
+
 func LoadConfigs(cmd *kingpin.CmdClause, fig *figtree.FigTree, opts interface{}) {
 	cmd.PreAction(func(_ *kingpin.ParseContext) error {
 		os.Setenv("JIRA_OPERATION", cmd.FullCommand())
@@ -174,6 +248,7 @@ func TemplateUsage(cmd *kingpin.CmdClause, opts *CommonOptions) {
 
 func JsonQueryUsage(cmd *kingpin.CmdClause, opts *CommonOptions) {
 	cmd.Flag("jq", "JSON Query to filter output").SetValue(&opts.JsonQuery)
+	cmd.Flag("jsonpath", "JSONPath expression to filter output; must resolve to exactly one value, there is no range/wrapping template to collect more").SetValue(&opts.JsonPath)
 	cmd.Flag("raw", "Return unquoted raw data from JSON Query").Hidden().SetValue(&opts.JsonQueryRaw)
 }
 
@@ -196,6 +271,35 @@ func (o *CommonOptions) PrintTemplate(data interface{}) error {
 		os.Stdout.Write([]byte{'\n'})
 		return err
 	}
+	if o.JsonPath.Value != "" {
+		buf := bytes.NewBufferString("")
+		RunTemplate("json", data, buf)
+		var parsed interface{}
+		if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+			return err
+		}
+		segments, err := parseJSONPath(o.JsonPath.Value)
+		if err != nil {
+			return err
+		}
+		values, err := evalJSONPath(parsed, segments)
+		if err != nil {
+			return err
+		}
+		if len(values) != 1 {
+			return fmt.Errorf("jsonpath %q resolved to %d values, expected exactly one", o.JsonPath.Value, len(values))
+		}
+		value, err := json.Marshal(values[0])
+		if err != nil {
+			return err
+		}
+		if o.JsonQueryRaw.Value {
+			value = []byte(strings.TrimPrefix(strings.TrimSuffix(string(value), "\""), "\""))
+		}
+		_, err = os.Stdout.Write(value)
+		os.Stdout.Write([]byte{'\n'})
+		return err
+	}
 	return RunTemplate(o.Template.Value, data, nil)
 }
 
@@ -262,12 +366,28 @@ func (o *CommonOptions) editFile(fileName string) (changes bool, err error) {
 	return false, err
 }
 
-func EditLoop(opts *CommonOptions, input interface{}, output interface{}, submit func() error) error {
+// EditLoop opens the rendered template in the user's editor, reparses the
+// result and submits it. When submitPatch is non-nil, EditLoop computes a
+// minimal patch between the original template render and the edited copy
+// (see diffPatch) and submits that via submitPatch instead of calling
+// submit with the full document; this avoids Jira rejecting untouched
+// custom fields on a wholesale update. submit is still used whenever
+// submitPatch is nil.
+func EditLoop(opts *CommonOptions, input interface{}, output interface{}, submit func() error, submitPatch func(patch map[string]interface{}) error) error {
 	tmpFile, err := tmpTemplate(opts.Template.Value, input)
 	if err != nil {
 		return err
 	}
 
+	origData, err := ioutil.ReadFile(tmpFile)
+	if err != nil {
+		return err
+	}
+	originalJSON, err := toCanonicalJSON(origData)
+	if err != nil {
+		return err
+	}
+
 	confirm := func(msg string) (answer bool) {
 		survey.AskOne(
 			&survey.Confirm{Message: msg, Default: true},
@@ -353,8 +473,33 @@ func EditLoop(opts *CommonOptions, input interface{}, output interface{}, submit
 			}
 			panic(Exit{Code: 1})
 		}
+
+		editedJSON, err := toCanonicalJSON(fixedYAML)
+		if err != nil {
+			log.Error(err.Error())
+			if confirm("Invalid YAML syntax, edit again?") {
+				continue
+			}
+			panic(Exit{Code: 1})
+		}
+
+		if len(opts.Preconditions) > 0 {
+			if err := checkPreconditions(opts.Preconditions, originalJSON, editedJSON); err != nil {
+				log.Error(err.Error())
+				if confirm("Precondition failed, edit again?") {
+					continue
+				}
+				panic(Exit{Code: 1})
+			}
+		}
+
 		// submit template
-		if err := submit(); err != nil {
+		if submitPatch != nil {
+			err = submitPatch(diffPatch(originalJSON, editedJSON))
+		} else {
+			err = submit()
+		}
+		if err != nil {
 			log.Error(err.Error())
 			if confirm("Jira reported an error, edit again?") {
 				continue
@@ -0,0 +1,267 @@
+package jiracli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/coryb/figtree"
+	"github.com/coryb/oreo"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	yaml "gopkg.in/coryb/yaml.v2"
+)
+
+// CredentialEntry is one named account in the credential store: enough to
+// pick an endpoint, a user and an auth backend, plus an indirection to
+// wherever the actual secret lives (keychain, pass, a file, ...) rather
+// than the secret itself.
+type CredentialEntry struct {
+	Endpoint  string `yaml:"endpoint"`
+	User      string `yaml:"user"`
+	AuthType  string `yaml:"auth-type,omitempty"`
+	SecretRef string `yaml:"secret-ref"`
+}
+
+// CredentialStore is the on-disk set of named credential entries, modeled
+// after git-bug's bridge auth store: one file, one entry per account,
+// secrets always referenced rather than stored inline.
+type CredentialStore struct {
+	path    string
+	Default string                     `yaml:"default,omitempty"`
+	Entries map[string]CredentialEntry `yaml:"entries"`
+}
+
+func credentialStorePath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "jira", "credentials.yml")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".config", "jira", "credentials.yml")
+}
+
+// LoadCredentialStore reads the credential store from disk, returning an
+// empty store (not an error) if it does not exist yet.
+func LoadCredentialStore() (*CredentialStore, error) {
+	store := &CredentialStore{
+		path:    credentialStorePath(),
+		Entries: map[string]CredentialEntry{},
+	}
+	data, err := ioutil.ReadFile(store.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, store); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Save writes the credential store back to disk, creating its parent
+// directory if necessary.
+func (s *CredentialStore) Save() error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0600)
+}
+
+// Get looks up a named credential entry.
+func (s *CredentialStore) Get(name string) (CredentialEntry, bool) {
+	entry, ok := s.Entries[name]
+	return entry, ok
+}
+
+// Set adds or replaces a named credential entry.
+func (s *CredentialStore) Set(name string, entry CredentialEntry) {
+	if s.Entries == nil {
+		s.Entries = map[string]CredentialEntry{}
+	}
+	s.Entries[name] = entry
+}
+
+// Remove deletes a named credential entry.
+func (s *CredentialStore) Remove(name string) {
+	delete(s.Entries, name)
+}
+
+// ApplyCredential resolves name against the credential store and copies its
+// endpoint, user, auth-type and secret into globals, so that Register's
+// --credential flag can select an account the same way --endpoint/--user/
+// --auth-type/--password-source/--token would. An empty name falls back to
+// the store's Default entry, if one was set via `jira cred use`.
+func ApplyCredential(globals *GlobalOptions, name string) error {
+	store, err := LoadCredentialStore()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = store.Default
+	}
+	if name == "" {
+		return nil
+	}
+	entry, ok := store.Get(name)
+	if !ok {
+		return fmt.Errorf("no credential named %q, run `jira cred list` to see what is configured", name)
+	}
+	globals.Endpoint = figtree.NewStringOption(entry.Endpoint)
+	globals.User = figtree.NewStringOption(entry.User)
+	if entry.AuthType != "" {
+		globals.AuthType = figtree.NewStringOption(entry.AuthType)
+	}
+	if entry.SecretRef != "" {
+		// route on the effective auth-type (entry.AuthType above already
+		// took precedence over any --auth-type flag), not entry.AuthType
+		// directly, so a credential with no stored auth-type still routes
+		// correctly when --auth-type is passed on the command line.
+		switch globals.AuthType.Value {
+		case "pat":
+			token, err := resolveSecretRef(entry.SecretRef)
+			if err != nil {
+				return fmt.Errorf("resolving secret-ref for credential %q: %s", name, err)
+			}
+			globals.Token = figtree.NewStringOption(token)
+		case "oauth1":
+			return fmt.Errorf("credential %q has a secret-ref but --auth-type=oauth1 needs --oauth1-* flags (consumer key, private key, access token/secret), not a single secret-ref", name)
+		default:
+			// basic and the legacy session flow both take a password;
+			// hand them the reference as-is rather than resolving it
+			// now, the same keychain:/pass:/file: shorthand
+			// --password-source already understands.
+			globals.PasswordSource = figtree.NewStringOption(entry.SecretRef)
+		}
+	}
+	return nil
+}
+
+var credAddName string
+var credAddEntry CredentialEntry
+var credRmName string
+var credUseName string
+
+// CredAddUsage wires up the flags/args for `jira cred add <name>`.
+func CredAddUsage(fig *figtree.FigTree, cmd *kingpin.CmdClause) error {
+	cmd.Arg("name", "Name for this credential entry").Required().StringVar(&credAddName)
+	cmd.Flag("endpoint", "Jira endpoint for this credential").Required().StringVar(&credAddEntry.Endpoint)
+	cmd.Flag("user", "Login name for this credential").Required().StringVar(&credAddEntry.User)
+	cmd.Flag("auth-type", "Authentication backend for this credential").StringVar(&credAddEntry.AuthType)
+	cmd.Flag("secret-ref", "Reference to the secret, e.g. keychain:work, pass:jira/work, file:~/.jira-work-token").Required().StringVar(&credAddEntry.SecretRef)
+	return nil
+}
+
+// CredAddExec implements `jira cred add <name>`.
+func CredAddExec(o *oreo.Client, globals *GlobalOptions) error {
+	store, err := LoadCredentialStore()
+	if err != nil {
+		return err
+	}
+	store.Set(credAddName, credAddEntry)
+	return store.Save()
+}
+
+// CredListUsage wires up `jira cred list`, which takes no flags.
+func CredListUsage(fig *figtree.FigTree, cmd *kingpin.CmdClause) error {
+	return nil
+}
+
+// CredListExec implements `jira cred list`.
+func CredListExec(o *oreo.Client, globals *GlobalOptions) error {
+	store, err := LoadCredentialStore()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(store.Entries))
+	for name := range store.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entry := store.Entries[name]
+		fmt.Printf("%s\t%s\t%s\t%s\n", name, entry.Endpoint, entry.User, entry.AuthType)
+	}
+	return nil
+}
+
+// CredRmUsage wires up the arg for `jira cred rm <name>`.
+func CredRmUsage(fig *figtree.FigTree, cmd *kingpin.CmdClause) error {
+	cmd.Arg("name", "Name of the credential entry to remove").Required().StringVar(&credRmName)
+	return nil
+}
+
+// CredRmExec implements `jira cred rm <name>`.
+func CredRmExec(o *oreo.Client, globals *GlobalOptions) error {
+	store, err := LoadCredentialStore()
+	if err != nil {
+		return err
+	}
+	store.Remove(credRmName)
+	return store.Save()
+}
+
+// CredUseUsage wires up the arg for `jira cred use <name>`.
+func CredUseUsage(fig *figtree.FigTree, cmd *kingpin.CmdClause) error {
+	cmd.Arg("name", "Name of the credential entry to use as the default").Required().StringVar(&credUseName)
+	return nil
+}
+
+// CredentialCommands returns the `cred add`/`cred list`/`cred rm`/`cred use`
+// entries for appending to the CommandRegistry slice passed to Register.
+func CredentialCommands() []CommandRegistry {
+	return []CommandRegistry{
+		{
+			Command: "cred add",
+			Entry: &CommandRegistryEntry{
+				Help:        "Add a named credential entry",
+				UsageFunc:   CredAddUsage,
+				ExecuteFunc: CredAddExec,
+			},
+		},
+		{
+			Command: "cred list",
+			Entry: &CommandRegistryEntry{
+				Help:        "List configured credential entries",
+				UsageFunc:   CredListUsage,
+				ExecuteFunc: CredListExec,
+			},
+		},
+		{
+			Command: "cred rm",
+			Entry: &CommandRegistryEntry{
+				Help:        "Remove a named credential entry",
+				UsageFunc:   CredRmUsage,
+				ExecuteFunc: CredRmExec,
+			},
+		},
+		{
+			Command: "cred use",
+			Entry: &CommandRegistryEntry{
+				Help:        "Set the default credential entry",
+				UsageFunc:   CredUseUsage,
+				ExecuteFunc: CredUseExec,
+			},
+		},
+	}
+}
+
+// CredUseExec implements `jira cred use <name>`: it persists name as the
+// default credential, so future invocations without --credential resolve
+// to it the same way the --credential global flag would for this one.
+func CredUseExec(o *oreo.Client, globals *GlobalOptions) error {
+	store, err := LoadCredentialStore()
+	if err != nil {
+		return err
+	}
+	if _, ok := store.Get(credUseName); !ok {
+		return fmt.Errorf("no credential named %q, run `jira cred list` to see what is configured", credUseName)
+	}
+	store.Default = credUseName
+	return store.Save()
+}
@@ -0,0 +1,146 @@
+package jiracli
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// withCredentialStoreHome points XDG_CONFIG_HOME at a scratch directory for
+// the duration of the test, so LoadCredentialStore/Save never touch the
+// real user config.
+func withCredentialStoreHome(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+}
+
+func TestApplyCredentialPassesFileSecretRefAsPasswordSource(t *testing.T) {
+	withCredentialStoreHome(t)
+
+	secretFile := filepath.Join(t.TempDir(), "work-password")
+	if err := ioutil.WriteFile(secretFile, []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := LoadCredentialStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("work", CredentialEntry{
+		Endpoint:  "https://work.example.com",
+		User:      "alice",
+		AuthType:  "basic",
+		SecretRef: "file:" + secretFile,
+	})
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	globals := &GlobalOptions{}
+	if err := ApplyCredential(globals, "work"); err != nil {
+		t.Fatalf("ApplyCredential() = %s", err)
+	}
+
+	if globals.PasswordSource.Value != "file:"+secretFile {
+		t.Errorf("globals.PasswordSource = %q, want %q", globals.PasswordSource.Value, "file:"+secretFile)
+	}
+	if globals.Token.Value != "" {
+		t.Errorf("globals.Token = %q, want empty for --auth-type=basic", globals.Token.Value)
+	}
+}
+
+func TestApplyCredentialResolvesFileSecretRefToToken(t *testing.T) {
+	withCredentialStoreHome(t)
+
+	secretFile := filepath.Join(t.TempDir(), "work-token")
+	if err := ioutil.WriteFile(secretFile, []byte("abc123\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := LoadCredentialStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("work", CredentialEntry{
+		Endpoint:  "https://work.example.com",
+		User:      "alice",
+		AuthType:  "pat",
+		SecretRef: "file:" + secretFile,
+	})
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	globals := &GlobalOptions{}
+	if err := ApplyCredential(globals, "work"); err != nil {
+		t.Fatalf("ApplyCredential() = %s", err)
+	}
+
+	if globals.Token.Value != "abc123" {
+		t.Errorf("globals.Token = %q, want %q", globals.Token.Value, "abc123")
+	}
+	if globals.PasswordSource.Value != "" {
+		t.Errorf("globals.PasswordSource = %q, want empty for --auth-type=pat", globals.PasswordSource.Value)
+	}
+}
+
+// Credential entries added without --auth-type (so entry.AuthType=="") rely
+// on a --auth-type flag supplied at invocation time; ApplyCredential must
+// route the secret-ref by that effective auth-type, not the stored one.
+func TestApplyCredentialRoutesSecretRefByEffectiveAuthType(t *testing.T) {
+	withCredentialStoreHome(t)
+
+	secretFile := filepath.Join(t.TempDir(), "work-token")
+	if err := ioutil.WriteFile(secretFile, []byte("abc123\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := LoadCredentialStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("work", CredentialEntry{
+		Endpoint:  "https://work.example.com",
+		User:      "alice",
+		SecretRef: "file:" + secretFile,
+	})
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	globals := &GlobalOptions{}
+	globals.AuthType.Value = "pat" // as if --auth-type=pat was passed on the command line
+	if err := ApplyCredential(globals, "work"); err != nil {
+		t.Fatalf("ApplyCredential() = %s", err)
+	}
+
+	if globals.Token.Value != "abc123" {
+		t.Errorf("globals.Token = %q, want %q", globals.Token.Value, "abc123")
+	}
+	if globals.PasswordSource.Value != "" {
+		t.Errorf("globals.PasswordSource = %q, want empty for --auth-type=pat", globals.PasswordSource.Value)
+	}
+}
+
+func TestApplyCredentialRejectsSecretRefForOAuth1(t *testing.T) {
+	withCredentialStoreHome(t)
+
+	store, err := LoadCredentialStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("work", CredentialEntry{
+		Endpoint:  "https://work.example.com",
+		User:      "alice",
+		AuthType:  "oauth1",
+		SecretRef: "file:/does/not/matter",
+	})
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	globals := &GlobalOptions{}
+	if err := ApplyCredential(globals, "work"); err == nil {
+		t.Fatal("expected ApplyCredential to reject a secret-ref on an oauth1 credential")
+	}
+}
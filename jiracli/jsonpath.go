@@ -0,0 +1,86 @@
+package jiracli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jsonPathSegmentRegexp matches one path segment of a kubectl-style
+// JSONPath expression: `.foo`, `[0]`, `[*]` or `["foo"]`.
+var jsonPathSegmentRegexp = regexp.MustCompile(`(\.[A-Za-z0-9_\-]+)|(\[\*\])|(\[\d+\])|(\[(?:'[^']*'|"[^"]*")\])`)
+
+// parseJSONPath normalizes the two forms kubectl accepts, `$.foo.bar[0].baz`
+// and `{.items[*].key}`, down to a plain slice of path segments. This is a
+// light-weight parser in the spirit of client-go's token source helpers,
+// not a full JSONPath engine: it only understands field access, numeric
+// indexing and the `[*]` wildcard.
+func parseJSONPath(expr string) ([]string, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimPrefix(expr, "{")
+	expr = strings.TrimSuffix(expr, "}")
+	expr = strings.TrimPrefix(expr, "$")
+	if expr == "" {
+		return nil, nil
+	}
+
+	matches := jsonPathSegmentRegexp.FindAllString(expr, -1)
+	if matches == nil || strings.Join(matches, "") != expr {
+		return nil, fmt.Errorf("invalid jsonpath expression %q", expr)
+	}
+
+	segments := make([]string, 0, len(matches))
+	for _, match := range matches {
+		switch {
+		case strings.HasPrefix(match, "."):
+			segments = append(segments, match[1:])
+		case match == "[*]":
+			segments = append(segments, "*")
+		default:
+			segments = append(segments, strings.Trim(match, `["']`))
+		}
+	}
+	return segments, nil
+}
+
+// evalJSONPath walks data following the segments produced by parseJSONPath.
+// A "*" segment fans out over a slice, so more than one value can come back
+// out the other end; PrintTemplate treats anything but exactly one result
+// as an error since there is no wrapping template to collect them here.
+func evalJSONPath(data interface{}, segments []string) ([]interface{}, error) {
+	values := []interface{}{data}
+	for _, segment := range segments {
+		var next []interface{}
+		for _, value := range values {
+			switch {
+			case segment == "*":
+				array, ok := value.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("jsonpath: [*] used against non-array value")
+				}
+				next = append(next, array...)
+			default:
+				if index, err := strconv.Atoi(segment); err == nil {
+					array, ok := value.([]interface{})
+					if !ok || index < 0 || index >= len(array) {
+						return nil, fmt.Errorf("jsonpath: index %d out of range", index)
+					}
+					next = append(next, array[index])
+					continue
+				}
+				object, ok := value.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("jsonpath: field %q used against non-object value", segment)
+				}
+				field, ok := object[segment]
+				if !ok {
+					return nil, fmt.Errorf("jsonpath: field %q not found", segment)
+				}
+				next = append(next, field)
+			}
+		}
+		values = next
+	}
+	return values, nil
+}
@@ -0,0 +1,140 @@
+package jiracli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"$.foo.bar", []string{"foo", "bar"}},
+		{"{.foo.bar}", []string{"foo", "bar"}},
+		{"$.items[0].key", []string{"items", "0", "key"}},
+		{"{.items[*].key}", []string{"items", "*", "key"}},
+		{`$.fields["custom-1"]`, []string{"fields", "custom-1"}},
+		{"$", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := parseJSONPath(tt.expr)
+		if err != nil {
+			t.Errorf("parseJSONPath(%q) returned error: %s", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseJSONPath(%q) = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseJSONPathInvalid(t *testing.T) {
+	for _, expr := range []string{"$.foo..bar", "$.foo[bar]", "$.foo bar"} {
+		if _, err := parseJSONPath(expr); err == nil {
+			t.Errorf("parseJSONPath(%q) expected an error, got nil", expr)
+		}
+	}
+}
+
+func TestEvalJSONPathFieldAccess(t *testing.T) {
+	data := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"summary": "a bug",
+		},
+	}
+	segments, err := parseJSONPath("$.fields.summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := evalJSONPath(data, segments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []interface{}{"a bug"}) {
+		t.Fatalf("evalJSONPath() = %#v", values)
+	}
+}
+
+func TestEvalJSONPathIndex(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []interface{}{"a", "b", "c"},
+	}
+	segments, err := parseJSONPath("$.items[1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := evalJSONPath(data, segments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []interface{}{"b"}) {
+		t.Fatalf("evalJSONPath() = %#v", values)
+	}
+}
+
+func TestEvalJSONPathIndexOutOfRange(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []interface{}{"a"},
+	}
+	segments, err := parseJSONPath("$.items[5]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := evalJSONPath(data, segments); err == nil {
+		t.Fatal("expected evalJSONPath to fail on an out of range index")
+	}
+}
+
+func TestEvalJSONPathMissingField(t *testing.T) {
+	data := map[string]interface{}{
+		"fields": map[string]interface{}{},
+	}
+	segments, err := parseJSONPath("$.fields.summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := evalJSONPath(data, segments); err == nil {
+		t.Fatal("expected evalJSONPath to fail on a missing field")
+	}
+}
+
+// TestEvalJSONPathWildcardResolvesToMultipleValues documents that [*]
+// fans out over an array instead of collecting it back into one value:
+// there is no wrapping/range template here to gather multiple matches,
+// so callers (PrintTemplate) must themselves reject anything but exactly
+// one result.
+func TestEvalJSONPathWildcardResolvesToMultipleValues(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"key": "FOO-1"},
+			map[string]interface{}{"key": "FOO-2"},
+		},
+	}
+	segments, err := parseJSONPath("{.items[*].key}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := evalJSONPath(data, segments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"FOO-1", "FOO-2"}
+	if !reflect.DeepEqual(values, want) {
+		t.Fatalf("evalJSONPath() = %#v, want %#v", values, want)
+	}
+}
+
+func TestEvalJSONPathWildcardAgainstNonArray(t *testing.T) {
+	data := map[string]interface{}{
+		"fields": map[string]interface{}{"summary": "a bug"},
+	}
+	segments, err := parseJSONPath("$.fields[*]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := evalJSONPath(data, segments); err == nil {
+		t.Fatal("expected evalJSONPath to fail when [*] is used against a non-array value")
+	}
+}
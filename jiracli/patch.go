@@ -0,0 +1,114 @@
+package jiracli
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	yaml "gopkg.in/coryb/yaml.v2"
+)
+
+// toCanonicalJSON runs yamlData through the same yamlFixup pass used
+// elsewhere in EditLoop and returns it as a map[string]interface{} built
+// from json.Marshal/Unmarshal, so that map keys, numbers and nested
+// structures compare the same way regardless of whether they came from
+// the original template render or the user's edited copy.
+func toCanonicalJSON(yamlData []byte) (map[string]interface{}, error) {
+	// parse with string-keyed maps, as EditLoop does before its own
+	// yamlFixup pass, so json.Marshal can encode the original render too
+	defer func(mapType, iface reflect.Type) {
+		yaml.DefaultMapType = mapType
+		yaml.IfaceType = iface
+	}(yaml.DefaultMapType, yaml.IfaceType)
+	yaml.DefaultMapType = reflect.TypeOf(map[string]interface{}{})
+	yaml.IfaceType = yaml.DefaultMapType.Elem()
+
+	var raw interface{}
+	if err := yaml.Unmarshal(yamlData, &raw); err != nil {
+		return nil, err
+	}
+	yamlFixup(&raw)
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(jsonData, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// diffPatch walks original and edited key-by-key (recursing into nested
+// maps) and returns a map containing only the keys that were added or
+// changed in edited, plus an explicit nil for any key that was removed.
+// Arrays are compared as whole values: changing any element replaces the
+// entire array in the patch rather than producing an element-level diff.
+func diffPatch(original, edited map[string]interface{}) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for key, editedValue := range edited {
+		originalValue, existed := original[key]
+		if !existed {
+			patch[key] = editedValue
+			continue
+		}
+		originalMap, originalIsMap := originalValue.(map[string]interface{})
+		editedMap, editedIsMap := editedValue.(map[string]interface{})
+		if originalIsMap && editedIsMap {
+			if sub := diffPatch(originalMap, editedMap); len(sub) > 0 {
+				patch[key] = sub
+			}
+			continue
+		}
+		if !reflect.DeepEqual(originalValue, editedValue) {
+			patch[key] = editedValue
+		}
+	}
+	for key := range original {
+		if _, stillPresent := edited[key]; !stillPresent {
+			patch[key] = nil
+		}
+	}
+	return patch
+}
+
+// lookupDottedPath resolves a "." separated path like "project.key"
+// against a canonical JSON document as produced by toCanonicalJSON.
+func lookupDottedPath(doc map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// checkPreconditions verifies that none of the dotted paths changed
+// between original and edited, mirroring the RequireKeyUnchanged
+// precondition pattern from kubectl edit. A path missing from original is
+// treated as unchanged, since it was never a field the user could have
+// touched in the template; a path present in original but removed from
+// edited is treated as a violation, the same as if its value had changed.
+func checkPreconditions(paths []string, original, edited map[string]interface{}) error {
+	for _, path := range paths {
+		originalValue, originalOk := lookupDottedPath(original, path)
+		if !originalOk {
+			continue
+		}
+		editedValue, editedOk := lookupDottedPath(edited, path)
+		if !editedOk {
+			return fmt.Errorf("%s is immutable, but was removed (was %v)", path, originalValue)
+		}
+		if !reflect.DeepEqual(originalValue, editedValue) {
+			return fmt.Errorf("%s is immutable, but changed from %v to %v", path, originalValue, editedValue)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package jiracli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffPatchNestedCustomField(t *testing.T) {
+	original := map[string]interface{}{
+		"summary": "old summary",
+		"customfield_10001": map[string]interface{}{
+			"value": "foo",
+			"id":    "1",
+		},
+	}
+	edited := map[string]interface{}{
+		"summary": "old summary",
+		"customfield_10001": map[string]interface{}{
+			"value": "bar",
+			"id":    "1",
+		},
+	}
+
+	patch := diffPatch(original, edited)
+
+	want := map[string]interface{}{
+		"customfield_10001": map[string]interface{}{
+			"value": "bar",
+		},
+	}
+	if !reflect.DeepEqual(patch, want) {
+		t.Fatalf("diffPatch() = %#v, want %#v", patch, want)
+	}
+}
+
+func TestDiffPatchArrayReplacement(t *testing.T) {
+	original := map[string]interface{}{
+		"labels": []interface{}{"a", "b"},
+	}
+	edited := map[string]interface{}{
+		"labels": []interface{}{"a", "c"},
+	}
+
+	patch := diffPatch(original, edited)
+
+	want := map[string]interface{}{
+		"labels": []interface{}{"a", "c"},
+	}
+	if !reflect.DeepEqual(patch, want) {
+		t.Fatalf("diffPatch() = %#v, want %#v", patch, want)
+	}
+}
+
+func TestDiffPatchDeletionAndUnchanged(t *testing.T) {
+	original := map[string]interface{}{
+		"summary":     "unchanged",
+		"description": "to be removed",
+	}
+	edited := map[string]interface{}{
+		"summary": "unchanged",
+	}
+
+	patch := diffPatch(original, edited)
+
+	want := map[string]interface{}{
+		"description": nil,
+	}
+	if !reflect.DeepEqual(patch, want) {
+		t.Fatalf("diffPatch() = %#v, want %#v", patch, want)
+	}
+}
+
+func TestCheckPreconditionsFailure(t *testing.T) {
+	original := map[string]interface{}{
+		"issuetype": "Bug",
+		"project": map[string]interface{}{
+			"key": "PROJ",
+		},
+	}
+	edited := map[string]interface{}{
+		"issuetype": "Story",
+		"project": map[string]interface{}{
+			"key": "PROJ",
+		},
+	}
+
+	err := checkPreconditions([]string{"issuetype", "project.key"}, original, edited)
+	if err == nil {
+		t.Fatal("expected checkPreconditions to fail when issuetype changes")
+	}
+}
+
+func TestCheckPreconditionsSuccess(t *testing.T) {
+	original := map[string]interface{}{
+		"issuetype": "Bug",
+		"project": map[string]interface{}{
+			"key": "PROJ",
+		},
+	}
+	edited := map[string]interface{}{
+		"issuetype": "Bug",
+		"summary":   "new summary",
+		"project": map[string]interface{}{
+			"key": "PROJ",
+		},
+	}
+
+	if err := checkPreconditions([]string{"issuetype", "project.key"}, original, edited); err != nil {
+		t.Fatalf("expected preconditions to pass, got %s", err)
+	}
+}
+
+func TestCheckPreconditionsFailureOnDeletedField(t *testing.T) {
+	original := map[string]interface{}{
+		"issuetype": "Bug",
+		"project": map[string]interface{}{
+			"key": "PROJ",
+		},
+	}
+	edited := map[string]interface{}{
+		"project": map[string]interface{}{
+			"key": "PROJ",
+		},
+	}
+
+	err := checkPreconditions([]string{"issuetype", "project.key"}, original, edited)
+	if err == nil {
+		t.Fatal("expected checkPreconditions to fail when issuetype is deleted entirely")
+	}
+}
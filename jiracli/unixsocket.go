@@ -0,0 +1,114 @@
+package jiracli
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// parseUnixEndpoint splits a `unix://` or `unix+tls://` endpoint of the
+// form `unix:///var/run/jira.sock/rest/api/2/...` into the on-disk socket
+// path and the remaining request path. ok is false if endpoint does not
+// use either scheme.
+func parseUnixEndpoint(endpoint string) (socketPath, requestPath string, useTLS bool, ok bool) {
+	switch {
+	case strings.HasPrefix(endpoint, "unix+tls://"):
+		useTLS = true
+		endpoint = strings.TrimPrefix(endpoint, "unix+tls://")
+	case strings.HasPrefix(endpoint, "unix://"):
+		endpoint = strings.TrimPrefix(endpoint, "unix://")
+	default:
+		return "", "", false, false
+	}
+
+	const sockSuffix = ".sock"
+	idx := strings.Index(endpoint, sockSuffix)
+	if idx == -1 {
+		return "", "", false, false
+	}
+	idx += len(sockSuffix)
+	return endpoint[:idx], endpoint[idx:], useTLS, true
+}
+
+// unixSocketTransport dials a fixed unix socket for every request instead
+// of using net/http's normal TCP dialer, and rewrites the request so that
+// the Host header carries the on-disk socket path (the only meaningful
+// "host" a socket-fronting service has) while req.URL.Host is left as the
+// placeholder net/http requires.
+type unixSocketTransport struct {
+	next       *http.Transport
+	socketPath string
+}
+
+func (t *unixSocketTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = "http"
+	req.URL.Host = "unix"
+	req.Host = t.socketPath
+	return t.next.RoundTrip(req)
+}
+
+// unixSocketEndpoint builds the transport and rewritten http(s) endpoint
+// for a `unix://`/`unix+tls://` GlobalOptions.Endpoint, using the same
+// Insecure flag as the regular TLS transport and the unix-socket-cert/key/ca
+// config keys for optional mTLS over the socket.
+func unixSocketEndpoint(globals *GlobalOptions) (http.RoundTripper, string, error) {
+	socketPath, requestPath, useTLS, ok := parseUnixEndpoint(globals.Endpoint.Value)
+	if !ok {
+		return nil, "", nil
+	}
+
+	dial := func(_ context.Context, _, _ string) (net.Conn, error) {
+		return net.Dial("unix", socketPath)
+	}
+	if useTLS {
+		tlsConfig := &tls.Config{
+			InsecureSkipVerify: globals.Insecure.Value,
+		}
+		if !tlsConfig.InsecureSkipVerify {
+			// crypto/tls refuses to dial at all unless ServerName or
+			// InsecureSkipVerify is set, but a unix socket has no DNS name
+			// to verify against: the server cert just needs to have been
+			// issued for this fixed, conventional name.
+			tlsConfig.ServerName = "localhost"
+		}
+		if globals.UnixSocketCA.Value != "" {
+			ca, err := ioutil.ReadFile(globals.UnixSocketCA.Value)
+			if err != nil {
+				return nil, "", err
+			}
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(ca)
+			tlsConfig.RootCAs = pool
+		}
+		if globals.UnixSocketCert.Value != "" && globals.UnixSocketKey.Value != "" {
+			cert, err := tls.LoadX509KeyPair(globals.UnixSocketCert.Value, globals.UnixSocketKey.Value)
+			if err != nil {
+				return nil, "", err
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		plainDial := dial
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := plainDial(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return tls.Client(conn, tlsConfig), nil
+		}
+	}
+
+	transport := &unixSocketTransport{
+		next:       &http.Transport{DialContext: dial},
+		socketPath: socketPath,
+	}
+
+	scheme := "http"
+	if useTLS {
+		scheme = "https"
+	}
+	return transport, scheme + "://unix" + requestPath, nil
+}